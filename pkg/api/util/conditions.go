@@ -0,0 +1,88 @@
+/*
+Copyright 2020 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package util contains small helpers shared by controllers that read and
+// write cert-manager API conditions.
+package util
+
+import (
+	cmapi "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/jetstack/cert-manager/pkg/apis/meta/v1"
+)
+
+// GetCertificateCondition returns the condition of the given type on crt,
+// or nil if it is not present.
+func GetCertificateCondition(crt *cmapi.Certificate, conditionType cmapi.CertificateConditionType) *cmapi.CertificateCondition {
+	for i := range crt.Status.Conditions {
+		if crt.Status.Conditions[i].Type == conditionType {
+			return &crt.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetCertificateCondition sets, or updates if it already exists, the
+// condition of the given type on crt.
+func SetCertificateCondition(crt *cmapi.Certificate, conditionType cmapi.CertificateConditionType, status cmmeta.ConditionStatus, reason, message string) {
+	newCondition := cmapi.CertificateCondition{
+		Type:    conditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+
+	for i, c := range crt.Status.Conditions {
+		if c.Type != conditionType {
+			continue
+		}
+		crt.Status.Conditions[i] = newCondition
+		return
+	}
+
+	crt.Status.Conditions = append(crt.Status.Conditions, newCondition)
+}
+
+// GetCertificateRequestCondition returns the condition of the given type
+// on cr, or nil if it is not present.
+func GetCertificateRequestCondition(cr *cmapi.CertificateRequest, conditionType cmapi.CertificateRequestConditionType) *cmapi.CertificateRequestCondition {
+	for i := range cr.Status.Conditions {
+		if cr.Status.Conditions[i].Type == conditionType {
+			return &cr.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetCertificateRequestCondition sets, or updates if it already exists,
+// the condition of the given type on cr.
+func SetCertificateRequestCondition(cr *cmapi.CertificateRequest, conditionType cmapi.CertificateRequestConditionType, status cmmeta.ConditionStatus, reason, message string) {
+	newCondition := cmapi.CertificateRequestCondition{
+		Type:    conditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+
+	for i, c := range cr.Status.Conditions {
+		if c.Type != conditionType {
+			continue
+		}
+		cr.Status.Conditions[i] = newCondition
+		return
+	}
+
+	cr.Status.Conditions = append(cr.Status.Conditions, newCondition)
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2020 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+const (
+	// RefreshCertificateAnnotationKey, if set to any non-empty value on a
+	// Certificate, requests that cert-manager force an out-of-band renewal
+	// of that Certificate, even if it is not yet due for renewal. Requires
+	// the AnnotationDrivenRefresh feature gate to be enabled. The value is
+	// cleared by cert-manager once the requested refresh has completed.
+	RefreshCertificateAnnotationKey = "cert-manager.io/refresh-certificate"
+
+	// RefreshCertificateNewPrivateKeyAnnotationValue, when set as the value
+	// of RefreshCertificateAnnotationKey, additionally requests that a new
+	// private key be generated as part of the forced renewal.
+	RefreshCertificateNewPrivateKeyAnnotationValue = "new-private-key"
+
+	// RefreshCertificateStatusAnnotationKey is set by cert-manager on a
+	// Certificate to report the progress of a refresh requested via
+	// RefreshCertificateAnnotationKey.
+	RefreshCertificateStatusAnnotationKey = "cert-manager.io/refresh-certificate-status"
+)
+
+// RefreshCertificateStatus is the set of values cert-manager writes to
+// RefreshCertificateStatusAnnotationKey.
+type RefreshCertificateStatus string
+
+const (
+	// RefreshCertificateStatusInProgress indicates a requested refresh has
+	// been accepted and a new issuance has been triggered.
+	RefreshCertificateStatusInProgress RefreshCertificateStatus = "in-progress"
+
+	// RefreshCertificateStatusDone indicates a requested refresh completed
+	// successfully.
+	RefreshCertificateStatusDone RefreshCertificateStatus = "done"
+
+	// RefreshCertificateStatusFailed indicates a requested refresh did not
+	// complete successfully.
+	RefreshCertificateStatusFailed RefreshCertificateStatus = "failed"
+)
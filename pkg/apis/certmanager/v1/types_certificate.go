@@ -0,0 +1,118 @@
+/*
+Copyright 2020 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cmmeta "github.com/jetstack/cert-manager/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// A Certificate resource should be created to ensure an up to date and signed
+// x509 certificate is stored in the Kubernetes Secret resource named in
+// `spec.secretName`.
+type Certificate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CertificateSpec   `json:"spec,omitempty"`
+	Status CertificateStatus `json:"status,omitempty"`
+}
+
+// CertificateSpec defines the desired state of Certificate.
+type CertificateSpec struct {
+	// SecretName is the name of the secret resource that will be automatically
+	// created and managed by this Certificate resource.
+	SecretName string `json:"secretName"`
+
+	// CommonName is a common name to be used on the Certificate.
+	// +optional
+	CommonName string `json:"commonName,omitempty"`
+
+	// IssuerRef is a reference to the issuer for this certificate.
+	IssuerRef cmmeta.ObjectReference `json:"issuerRef"`
+}
+
+// CertificateStatus defines the observed state of Certificate.
+type CertificateStatus struct {
+	// +optional
+	Conditions []CertificateCondition `json:"conditions,omitempty"`
+
+	// NotAfter is the timestamp at which the certificate stored in the
+	// secret named by this resource expires.
+	// +optional
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+
+	// NotBefore is the timestamp at which the certificate stored in the
+	// secret named by this resource becomes valid.
+	// +optional
+	NotBefore *metav1.Time `json:"notBefore,omitempty"`
+
+	// RenewalTime is the time at which the certificate stored in this
+	// resource's secret is expected to be renewed.
+	// +optional
+	RenewalTime *metav1.Time `json:"renewalTime,omitempty"`
+}
+
+// CertificateConditionType represents a Certificate condition value.
+type CertificateConditionType string
+
+const (
+	// CertificateConditionReady indicates that a certificate is ready for
+	// use. This is defined as:
+	// - The corresponding Secret exists
+	// - The Secret contains a certificate that has not expired
+	// - The Secret contains a private key valid for the certificate
+	// - The CommonName attribute matches that specified on the
+	//   Certificate
+	CertificateConditionReady CertificateConditionType = "Ready"
+
+	// CertificateConditionIssuing indicates that a certificate is actively
+	// being issued or reissued.
+	CertificateConditionIssuing CertificateConditionType = "Issuing"
+)
+
+// CertificateCondition contains condition information for a Certificate.
+type CertificateCondition struct {
+	// Type of the condition, known values are ('Ready', 'Issuing').
+	Type CertificateConditionType `json:"type"`
+
+	// Status of the condition, one of ('True', 'False', 'Unknown').
+	Status cmmeta.ConditionStatus `json:"status"`
+
+	// Reason is a brief machine readable explanation for the condition's
+	// last transition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Message is a human readable description of the details of the last
+	// transition, complementing reason.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CertificateList is a list of Certificates.
+type CertificateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Certificate `json:"items"`
+}
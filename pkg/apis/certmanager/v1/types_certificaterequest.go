@@ -0,0 +1,107 @@
+/*
+Copyright 2020 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cmmeta "github.com/jetstack/cert-manager/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// A CertificateRequest is used to request a signed certificate from one of
+// the configured issuers, via the IssuerRef field.
+type CertificateRequest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CertificateRequestSpec   `json:"spec,omitempty"`
+	Status CertificateRequestStatus `json:"status,omitempty"`
+}
+
+// CertificateRequestSpec defines the desired state of CertificateRequest.
+type CertificateRequestSpec struct {
+	// IssuerRef is a reference to the issuer that should sign this
+	// CertificateRequest.
+	IssuerRef cmmeta.ObjectReference `json:"issuerRef"`
+
+	// Request is the raw PEM encoded PKCS#10 CSR to be submitted for
+	// signing.
+	Request []byte `json:"request"`
+}
+
+// CertificateRequestStatus defines the observed state of CertificateRequest.
+type CertificateRequestStatus struct {
+	// +optional
+	Conditions []CertificateRequestCondition `json:"conditions,omitempty"`
+
+	// Certificate is the PEM encoded signed certificate chain, populated
+	// once issuance has succeeded.
+	// +optional
+	Certificate []byte `json:"certificate,omitempty"`
+
+	// CA is the PEM encoded certificate authority chain returned by the
+	// issuer, populated once issuance has succeeded.
+	// +optional
+	CA []byte `json:"ca,omitempty"`
+}
+
+// CertificateRequestConditionType represents a CertificateRequest condition
+// value.
+type CertificateRequestConditionType string
+
+const (
+	// CertificateRequestConditionReady indicates that the request has been
+	// signed and Status.Certificate is populated.
+	CertificateRequestConditionReady CertificateRequestConditionType = "Ready"
+)
+
+// CertificateRequestReason strings are set on the Reason field of a
+// CertificateRequestCondition.
+const (
+	// CertificateRequestReasonPending indicates the request is being
+	// processed.
+	CertificateRequestReasonPending = "Pending"
+
+	// CertificateRequestReasonIssued indicates the request was signed
+	// successfully.
+	CertificateRequestReasonIssued = "Issued"
+
+	// CertificateRequestReasonFailed indicates signing the request
+	// terminally failed and will not be retried.
+	CertificateRequestReasonFailed = "Failed"
+)
+
+// CertificateRequestCondition contains condition information for a
+// CertificateRequest.
+type CertificateRequestCondition struct {
+	Type    CertificateRequestConditionType `json:"type"`
+	Status  cmmeta.ConditionStatus          `json:"status"`
+	Reason  string                          `json:"reason,omitempty"`
+	Message string                          `json:"message,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CertificateRequestList is a list of CertificateRequests.
+type CertificateRequestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CertificateRequest `json:"items"`
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2020 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// An Issuer represents the configuration for a single signing authority
+// within a single namespace.
+type Issuer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   IssuerSpec   `json:"spec,omitempty"`
+	Status IssuerStatus `json:"status,omitempty"`
+}
+
+// IssuerSpec is the specification of an Issuer. Exactly one of the fields
+// below must be set.
+type IssuerSpec struct {
+	// +optional
+	ExternalPlugin *ExternalPluginIssuer `json:"externalPlugin,omitempty"`
+}
+
+// IssuerStatus defines the observed state of Issuer.
+type IssuerStatus struct {
+	// +optional
+	Conditions []IssuerCondition `json:"conditions,omitempty"`
+}
+
+// IssuerConditionType represents an Issuer condition value.
+type IssuerConditionType string
+
+const (
+	// IssuerConditionReady indicates that the Issuer has validated its
+	// configuration and is able to issue certificates.
+	IssuerConditionReady IssuerConditionType = "Ready"
+)
+
+// IssuerCondition contains condition information for an Issuer.
+type IssuerCondition struct {
+	Type    IssuerConditionType `json:"type"`
+	Status  string              `json:"status"`
+	Reason  string              `json:"reason,omitempty"`
+	Message string              `json:"message,omitempty"`
+}
+
+// ExternalPluginIssuer configures cert-manager to sign CertificateRequests
+// by delegating to an external binary, rather than via a built-in issuer
+// type. The plugin is invoked once per CertificateRequest: the CSR and
+// issuer configuration are written to its stdin as a SignRequest, and a
+// SignResponse is read back from its stdout. See
+// pkg/apis/signer/v1alpha1 for the exchanged envelope types.
+type ExternalPluginIssuer struct {
+	// CommandPath is the path to the plugin binary that cert-manager will
+	// exec for every CertificateRequest. Mutually exclusive with
+	// SocketPath.
+	// +optional
+	CommandPath string `json:"commandPath,omitempty"`
+
+	// CommandArgs are additional arguments passed to CommandPath.
+	// +optional
+	CommandArgs []string `json:"commandArgs,omitempty"`
+
+	// SocketPath is the path to a Unix domain socket on which the plugin
+	// listens. cert-manager writes one newline-terminated JSON SignRequest
+	// to the socket and reads one newline-terminated JSON SignResponse
+	// back; gRPC is not used. Mutually exclusive with CommandPath.
+	// +optional
+	SocketPath string `json:"socketPath,omitempty"`
+
+	// Config is arbitrary, plugin-specific configuration that is passed
+	// through to the plugin unmodified on every SignRequest.
+	// +optional
+	Config *ExternalPluginConfig `json:"config,omitempty"`
+
+	// TimeoutSeconds bounds how long cert-manager will wait for the
+	// plugin to respond before treating the call as failed. Defaults to
+	// 30 seconds.
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// ExternalPluginConfig is an opaque, plugin-defined configuration blob,
+// forwarded verbatim in every SignRequest.
+type ExternalPluginConfig struct {
+	// Raw holds the plugin configuration, serialised as JSON.
+	// +optional
+	Raw []byte `json:"-"`
+}
+
+// MarshalJSON returns Raw unmodified, so that arbitrary plugin-defined
+// configuration round-trips through the API server as opaque JSON rather
+// than being base64-encoded as a []byte normally would.
+func (c ExternalPluginConfig) MarshalJSON() ([]byte, error) {
+	if len(c.Raw) == 0 {
+		return []byte("null"), nil
+	}
+	return c.Raw, nil
+}
+
+// UnmarshalJSON stores data verbatim in Raw.
+func (c *ExternalPluginConfig) UnmarshalJSON(data []byte) error {
+	if c == nil {
+		return fmt.Errorf("ExternalPluginConfig: UnmarshalJSON on nil pointer")
+	}
+	c.Raw = append(c.Raw[0:0], data...)
+	return nil
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2020 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package v1alpha1 contains the CertificateExpiryMonitor API used to point
+// the cluster certificate expiry collector at X.509 material that was not
+// issued by cert-manager.
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// A CertificateExpiryMonitor lists Secrets and ConfigMaps containing
+// arbitrary PEM-encoded X.509 certificates whose expiry cert-manager
+// should track and expose, even though it did not issue them. This is
+// intended for cluster-critical certificates such as the kube-apiserver
+// serving certificate or an ingress controller's default certificate.
+type CertificateExpiryMonitor struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec CertificateExpiryMonitorSpec `json:"spec,omitempty"`
+}
+
+// CertificateExpiryMonitorSpec defines the sources of certificate
+// material a CertificateExpiryMonitor should scrape.
+type CertificateExpiryMonitorSpec struct {
+	// Sources is the list of PEM sources to parse and export expiry for.
+	Sources []CertificateExpirySource `json:"sources"`
+}
+
+// NamespacedObjectReference is a reference to a named object within a
+// specific namespace.
+type NamespacedObjectReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// CertificateExpirySource references a single key within a Secret or
+// ConfigMap that holds PEM-encoded certificate data.
+type CertificateExpirySource struct {
+	// Name is a human-readable label for this source, used as the
+	// "source" label on the exported metric. Defaults to
+	// "<kind>/<namespace>/<name>/<key>" if unset, where <key> is Key as
+	// written (empty when Key is unset).
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// SecretRef points at a Secret containing the certificate data. Exactly
+	// one of SecretRef or ConfigMapRef must be set.
+	// +optional
+	SecretRef *NamespacedObjectReference `json:"secretRef,omitempty"`
+
+	// ConfigMapRef points at a ConfigMap containing the certificate data.
+	// Exactly one of SecretRef or ConfigMapRef must be set.
+	// +optional
+	ConfigMapRef *NamespacedObjectReference `json:"configMapRef,omitempty"`
+
+	// Key is the key within the referenced Secret/ConfigMap's data that
+	// holds the PEM-encoded certificate. Defaults to "tls.crt".
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CertificateExpiryMonitorList is a list of CertificateExpiryMonitors.
+type CertificateExpiryMonitorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CertificateExpiryMonitor `json:"items"`
+}
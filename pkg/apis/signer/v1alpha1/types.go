@@ -0,0 +1,71 @@
+/*
+Copyright 2020 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package v1alpha1 defines the JSON envelope exchanged between
+// cert-manager and an external signer plugin, whether invoked as a
+// subprocess over stdin/stdout or dialled on a Unix domain socket, over
+// which the envelope is sent as one line of JSON each way (not gRPC).
+//
+// The envelope is intentionally modelled on client-go's exec credential
+// plugin protocol: a single versioned request is written, and a single
+// versioned response of the same apiVersion is expected back.
+package v1alpha1
+
+// APIVersion is the only supported value of SignRequest.APIVersion and
+// SignResponse.APIVersion. Plugins must reject requests carrying any
+// other value.
+const APIVersion = "signer.cert-manager.io/v1alpha1"
+
+// SignRequest is written to the plugin's stdin (or to its Unix domain
+// socket) for every CertificateRequest it is asked to sign.
+type SignRequest struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"` // "SignRequest"
+
+	// CSRPEM is the PEM encoded PKCS#10 certificate signing request.
+	CSRPEM []byte `json:"csrPEM"`
+
+	// IssuerConfig is the raw, plugin-specific configuration copied from
+	// the Issuer's spec.externalPlugin.config.
+	// +optional
+	IssuerConfig []byte `json:"issuerConfig,omitempty"`
+}
+
+// SignResponse is read back from the plugin's stdout (or from its Unix
+// domain socket) in answer to a SignRequest.
+type SignResponse struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"` // "SignResponse"
+
+	// CertificatePEM is the PEM encoded, signed certificate chain.
+	// +optional
+	CertificatePEM []byte `json:"certificatePEM,omitempty"`
+
+	// CAPEM is the PEM encoded issuing CA certificate, if the plugin has
+	// one to return.
+	// +optional
+	CAPEM []byte `json:"caPEM,omitempty"`
+
+	// Error, if non-empty, indicates the sign request failed.
+	// +optional
+	Error string `json:"error,omitempty"`
+
+	// Retryable indicates whether Error represents a transient failure
+	// that is safe to retry (e.g. a backend CA timeout), as opposed to a
+	// terminal failure (e.g. a malformed CSR) that should not be retried.
+	// +optional
+	Retryable bool `json:"retryable,omitempty"`
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2020 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	apiutil "github.com/jetstack/cert-manager/pkg/api/util"
+	cmapi "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/jetstack/cert-manager/pkg/apis/meta/v1"
+	cmclient "github.com/jetstack/cert-manager/pkg/client/clientset/versioned"
+	cminformers "github.com/jetstack/cert-manager/pkg/client/informers/externalversions"
+	cmlisters "github.com/jetstack/cert-manager/pkg/client/listers/certmanager/v1"
+	"github.com/jetstack/cert-manager/pkg/metrics"
+)
+
+// ControllerName is the name this controller is registered and recorded
+// against in the controllerpkg sync call counter.
+const ControllerName = "certificaterequests-issuer-external"
+
+const (
+	reasonIssued = "Issued"
+	reasonFailed = "ExternalSignerError"
+)
+
+// controller watches CertificateRequests referencing an Issuer of type
+// ExternalPlugin and drives them to completion by calling out to the
+// configured plugin via Signer.
+type controller struct {
+	certificateRequestLister cmlisters.CertificateRequestLister
+	issuerLister             cmlisters.IssuerLister
+	cmClient                 cmclient.Interface
+	recorder                 record.EventRecorder
+	signer                   *Signer
+}
+
+// NewController returns a new external-plugin CertificateRequest
+// controller, the workqueue it feeds from, and the set of informer sync
+// functions that must return true before it may start processing items.
+func NewController(
+	cmFactory cminformers.SharedInformerFactory,
+	cmClient cmclient.Interface,
+	recorder record.EventRecorder,
+	m *metrics.Metrics,
+) (*controller, workqueue.RateLimitingInterface, []cache.InformerSynced) {
+	certificateRequestInformer := cmFactory.Certmanager().V1().CertificateRequests()
+	issuerInformer := cmFactory.Certmanager().V1().Issuers()
+
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName)
+
+	certificateRequestInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { enqueue(queue, obj) },
+		UpdateFunc: func(_, obj interface{}) { enqueue(queue, obj) },
+	})
+
+	c := &controller{
+		certificateRequestLister: certificateRequestInformer.Lister(),
+		issuerLister:             issuerInformer.Lister(),
+		cmClient:                 cmClient,
+		recorder:                 recorder,
+		signer:                   New(m),
+	}
+
+	return c, queue, []cache.InformerSynced{
+		certificateRequestInformer.Informer().HasSynced,
+		issuerInformer.Informer().HasSynced,
+	}
+}
+
+func enqueue(queue workqueue.RateLimitingInterface, obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	queue.Add(key)
+}
+
+// ProcessItem is the controller's sync function. It signs the
+// CertificateRequest named by key if it references an ExternalPlugin
+// Issuer and has not already reached a terminal Ready state.
+func (c *controller) ProcessItem(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return fmt.Errorf("invalid resource key: %s", key)
+	}
+
+	cr, err := c.certificateRequestLister.CertificateRequests(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if ready := apiutil.GetCertificateRequestCondition(cr, cmapi.CertificateRequestConditionReady); ready != nil {
+		if ready.Reason == cmapi.CertificateRequestReasonIssued || ready.Reason == cmapi.CertificateRequestReasonFailed {
+			// Already reached a terminal state; nothing further to do.
+			return nil
+		}
+	}
+
+	if cr.Spec.IssuerRef.Kind != "" && cr.Spec.IssuerRef.Kind != "Issuer" {
+		return nil
+	}
+
+	issuer, err := c.issuerLister.Issuers(namespace).Get(cr.Spec.IssuerRef.Name)
+	if err != nil {
+		return err
+	}
+
+	if issuer.Spec.ExternalPlugin == nil {
+		// Not ours to sign.
+		return nil
+	}
+
+	return c.sign(ctx, cr, issuer)
+}
+
+func (c *controller) sign(ctx context.Context, cr *cmapi.CertificateRequest, issuer *cmapi.Issuer) error {
+	certPEM, caPEM, signErr := c.signer.Sign(ctx, issuer.Name, issuer.Spec.ExternalPlugin, cr.Spec.Request)
+
+	cr = cr.DeepCopy()
+
+	if signErr == nil {
+		cr.Status.Certificate = certPEM
+		cr.Status.CA = caPEM
+		apiutil.SetCertificateRequestCondition(cr, cmapi.CertificateRequestConditionReady, cmmeta.ConditionTrue, cmapi.CertificateRequestReasonIssued, "Certificate signed successfully by external plugin")
+
+		if _, err := c.cmClient.CertmanagerV1().CertificateRequests(cr.Namespace).UpdateStatus(ctx, cr, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		c.recorder.Event(cr, corev1.EventTypeNormal, reasonIssued, "Certificate request signed by external plugin")
+		return nil
+	}
+
+	var exitErr *pluginExitError
+	retryable := !errors.As(signErr, &exitErr) || exitErr.Retryable()
+
+	reason := cmapi.CertificateRequestReasonPending
+	if !retryable {
+		reason = cmapi.CertificateRequestReasonFailed
+	}
+	apiutil.SetCertificateRequestCondition(cr, cmapi.CertificateRequestConditionReady, cmmeta.ConditionFalse, reason, signErr.Error())
+
+	if _, err := c.cmClient.CertmanagerV1().CertificateRequests(cr.Namespace).UpdateStatus(ctx, cr, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	c.recorder.Eventf(cr, corev1.EventTypeWarning, reasonFailed, "External signer plugin call failed: %s", signErr)
+
+	if !retryable {
+		return nil
+	}
+	return signErr
+}
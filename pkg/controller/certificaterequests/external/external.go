@@ -0,0 +1,191 @@
+/*
+Copyright 2020 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package external signs CertificateRequests by delegating to an external
+// plugin binary, configured on an Issuer of type ExternalPlugin. The
+// plugin is invoked either as a subprocess (CommandPath) or dialled as a
+// Unix domain socket (SocketPath); either way the exchange is a single
+// signerv1alpha1.SignRequest written out and a single SignResponse read
+// back, as JSON. Over the socket each message is terminated by a newline;
+// a subprocess gets the request on stdin and its whole stdout is read as
+// the response.
+package external
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"time"
+
+	cmapi "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1"
+	signerv1alpha1 "github.com/jetstack/cert-manager/pkg/apis/signer/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/metrics"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// pluginExitError is returned for exec-based plugins that exit non-zero,
+// and, with exit code 1, for a SignResponse from any plugin that carries a
+// non-retryable Error, allowing the caller to distinguish retryable from
+// terminal failures.
+type pluginExitError struct {
+	exitCode int
+	err      error
+}
+
+func (e *pluginExitError) Error() string { return e.err.Error() }
+
+// Retryable reports whether a plugin failure should be retried. By
+// convention, exit code 1 signals a terminal (non-retryable) failure (for
+// example, a malformed CSR), while any other non-zero exit code or a
+// transport error is treated as retryable.
+func (e *pluginExitError) Retryable() bool {
+	return e.exitCode != 1
+}
+
+// Signer signs CertificateRequests by invoking an ExternalPlugin issuer's
+// configured plugin binary or socket.
+type Signer struct {
+	metrics *metrics.Metrics
+}
+
+// New returns a Signer that records call outcomes against m.
+func New(m *metrics.Metrics) *Signer {
+	return &Signer{metrics: m}
+}
+
+// Sign submits csrPEM to the plugin configured on issuer and returns the
+// signed certificate chain and CA PEM on success.
+func (s *Signer) Sign(ctx context.Context, pluginName string, issuer *cmapi.ExternalPluginIssuer, csrPEM []byte) (certPEM, caPEM []byte, err error) {
+	timeout := defaultTimeout
+	if issuer.TimeoutSeconds != nil {
+		timeout = time.Duration(*issuer.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req := &signerv1alpha1.SignRequest{
+		APIVersion: signerv1alpha1.APIVersion,
+		Kind:       "SignRequest",
+		CSRPEM:     csrPEM,
+	}
+	if issuer.Config != nil {
+		req.IssuerConfig = issuer.Config.Raw
+	}
+
+	var resp *signerv1alpha1.SignResponse
+	switch {
+	case issuer.CommandPath != "":
+		resp, err = s.callExec(ctx, issuer, req)
+	case issuer.SocketPath != "":
+		resp, err = s.callSocket(ctx, issuer.SocketPath, req)
+	default:
+		err = fmt.Errorf("externalPlugin issuer must set either commandPath or socketPath")
+	}
+
+	if err == nil && resp.Error != "" {
+		if resp.Retryable {
+			err = fmt.Errorf("external signer plugin returned a retryable error: %s", resp.Error)
+		} else {
+			err = &pluginExitError{exitCode: 1, err: fmt.Errorf("external signer plugin returned a terminal error: %s", resp.Error)}
+		}
+	}
+
+	result := metrics.ExternalSignerResultSuccess
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		result = metrics.ExternalSignerResultTimeout
+	case err != nil:
+		result = metrics.ExternalSignerResultError
+	}
+	s.metrics.IncrementExternalSignerCalls(pluginName, result)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resp.CertificatePEM, resp.CAPEM, nil
+}
+
+func (s *Signer) callExec(ctx context.Context, issuer *cmapi.ExternalPluginIssuer, req *signerv1alpha1.SignRequest) (*signerv1alpha1.SignResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal SignRequest: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, issuer.CommandPath, issuer.CommandArgs...)
+	cmd.Stdin = bytes.NewReader(body)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, &pluginExitError{
+				exitCode: exitErr.ExitCode(),
+				err:      fmt.Errorf("plugin exited with code %d: %s", exitErr.ExitCode(), stderr.String()),
+			}
+		}
+		return nil, fmt.Errorf("failed to run plugin: %w", err)
+	}
+
+	return decodeSignResponse(stdout.Bytes())
+}
+
+func (s *Signer) callSocket(ctx context.Context, socketPath string, req *signerv1alpha1.SignRequest) (*signerv1alpha1.SignResponse, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial plugin socket %q: %w", socketPath, err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal SignRequest: %w", err)
+	}
+	if _, err := conn.Write(append(body, '\n')); err != nil {
+		return nil, fmt.Errorf("failed to write SignRequest to plugin socket: %w", err)
+	}
+
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SignResponse from plugin socket: %w", err)
+	}
+
+	return decodeSignResponse(line)
+}
+
+func decodeSignResponse(data []byte) (*signerv1alpha1.SignResponse, error) {
+	var resp signerv1alpha1.SignResponse
+	if err := json.Unmarshal(bytes.TrimSpace(data), &resp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal SignResponse: %w", err)
+	}
+	if resp.APIVersion != signerv1alpha1.APIVersion {
+		return nil, fmt.Errorf("unsupported SignResponse apiVersion %q, expected %q", resp.APIVersion, signerv1alpha1.APIVersion)
+	}
+	return &resp, nil
+}
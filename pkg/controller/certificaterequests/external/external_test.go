@@ -0,0 +1,153 @@
+/*
+Copyright 2020 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	fakeclock "k8s.io/utils/clock/testing"
+
+	cmapi "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/jetstack/cert-manager/pkg/logs"
+	"github.com/jetstack/cert-manager/pkg/metrics"
+)
+
+func newTestSigner(t *testing.T) *Signer {
+	t.Helper()
+	return New(metrics.New(logs.Log, fakeclock.NewFakeClock(time.Now())))
+}
+
+// shellIssuer configures an ExternalPlugin issuer whose plugin is the
+// given /bin/sh script, reading the SignRequest from stdin and writing
+// the SignResponse to stdout.
+func shellIssuer(script string, timeoutSeconds int32) *cmapi.ExternalPluginIssuer {
+	return &cmapi.ExternalPluginIssuer{
+		CommandPath:    "/bin/sh",
+		CommandArgs:    []string{"-c", script},
+		TimeoutSeconds: &timeoutSeconds,
+	}
+}
+
+func TestSign_Success(t *testing.T) {
+	s := newTestSigner(t)
+	issuer := shellIssuer(`cat <<'EOF'
+{"apiVersion":"signer.cert-manager.io/v1alpha1","kind":"SignResponse","certificatePEM":"Y2VydA==","caPEM":"Y2E="}
+EOF`, 5)
+
+	certPEM, caPEM, err := s.Sign(context.Background(), "test-plugin", issuer, []byte("csr"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(certPEM) != "cert" {
+		t.Errorf("unexpected certPEM: %q", certPEM)
+	}
+	if string(caPEM) != "ca" {
+		t.Errorf("unexpected caPEM: %q", caPEM)
+	}
+}
+
+func TestSign_TerminalExitCode(t *testing.T) {
+	s := newTestSigner(t)
+	issuer := shellIssuer(`exit 1`, 5)
+
+	_, _, err := s.Sign(context.Background(), "test-plugin", issuer, []byte("csr"))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	var exitErr *pluginExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("expected a *pluginExitError, got %T: %v", err, err)
+	}
+	if exitErr.Retryable() {
+		t.Error("expected exit code 1 to be classified as terminal (non-retryable)")
+	}
+}
+
+func TestSign_RetryableExitCode(t *testing.T) {
+	s := newTestSigner(t)
+	issuer := shellIssuer(`exit 17`, 5)
+
+	_, _, err := s.Sign(context.Background(), "test-plugin", issuer, []byte("csr"))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	var exitErr *pluginExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("expected a *pluginExitError, got %T: %v", err, err)
+	}
+	if !exitErr.Retryable() {
+		t.Error("expected a non-1 exit code to be classified as retryable")
+	}
+}
+
+func TestSign_ApplicationError(t *testing.T) {
+	s := newTestSigner(t)
+	issuer := shellIssuer(`cat <<'EOF'
+{"apiVersion":"signer.cert-manager.io/v1alpha1","kind":"SignResponse","error":"CSR rejected","retryable":false}
+EOF`, 5)
+
+	_, _, err := s.Sign(context.Background(), "test-plugin", issuer, []byte("csr"))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	var exitErr *pluginExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("expected a *pluginExitError for a non-retryable application error, got %T: %v", err, err)
+	}
+}
+
+func TestSign_Timeout(t *testing.T) {
+	s := newTestSigner(t)
+	issuer := shellIssuer(`sleep 5`, 1)
+
+	start := time.Now()
+	_, _, err := s.Sign(context.Background(), "test-plugin", issuer, []byte("csr"))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if elapsed := time.Since(start); elapsed >= 5*time.Second {
+		t.Errorf("Sign did not return once the context timeout elapsed, took %s", elapsed)
+	}
+
+	// exec.CommandContext kills the plugin on timeout rather than letting it
+	// exit on its own, so the failure surfaces as a pluginExitError for the
+	// killed process, not a wrapped context.DeadlineExceeded.
+	var exitErr *pluginExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("expected a *pluginExitError for a killed plugin, got %T: %v", err, err)
+	}
+}
+
+func TestDecodeSignResponse_MalformedJSON(t *testing.T) {
+	_, err := decodeSignResponse([]byte(`not json`))
+	if err == nil {
+		t.Fatal("expected an error decoding malformed JSON")
+	}
+}
+
+func TestDecodeSignResponse_WrongAPIVersion(t *testing.T) {
+	_, err := decodeSignResponse([]byte(`{"apiVersion":"signer.cert-manager.io/v1alpha2","kind":"SignResponse"}`))
+	if err == nil {
+		t.Fatal("expected an error for an unsupported apiVersion")
+	}
+}
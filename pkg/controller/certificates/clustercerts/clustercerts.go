@@ -0,0 +1,270 @@
+/*
+Copyright 2020 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package clustercerts implements a controller that periodically parses
+// arbitrary X.509 material referenced by CertificateExpiryMonitor
+// resources and exposes its expiry via pkg/metrics, even for certificates
+// that were not issued by cert-manager (e.g. the kube-apiserver serving
+// certificate). Gated behind the ClusterCertificateExpiryMonitor feature.
+package clustercerts
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	k8sinformers "k8s.io/client-go/informers"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	clustercertsv1alpha1 "github.com/jetstack/cert-manager/pkg/apis/clustercerts/v1alpha1"
+	cminformers "github.com/jetstack/cert-manager/pkg/client/informers/externalversions"
+	clustercertslisters "github.com/jetstack/cert-manager/pkg/client/listers/clustercerts/v1alpha1"
+	"github.com/jetstack/cert-manager/pkg/metrics"
+)
+
+// ControllerName is the name used to register this controller and to
+// label its certmanager_controller_sync_call_count series.
+const ControllerName = "cluster-certificate-expiry-monitor"
+
+const defaultKey = "tls.crt"
+
+// controller re-parses the X.509 material referenced by each
+// CertificateExpiryMonitor and keeps pkg/metrics' managed-certificate
+// expiry gauge in sync with it.
+type controller struct {
+	monitorLister   clustercertslisters.CertificateExpiryMonitorLister
+	secretLister    corelisters.SecretLister
+	configMapLister corelisters.ConfigMapLister
+	metrics         *metrics.Metrics
+}
+
+// NewController returns a new cluster certificate expiry controller, the
+// workqueue it feeds from, and the set of informer sync functions that
+// must return true before it may start processing items.
+func NewController(
+	factory k8sinformers.SharedInformerFactory,
+	clustercertsFactory cminformers.SharedInformerFactory,
+	m *metrics.Metrics,
+) (*controller, workqueue.RateLimitingInterface, []cache.InformerSynced) {
+	monitorInformer := clustercertsFactory.Clustercerts().V1alpha1().CertificateExpiryMonitors()
+	secretInformer := factory.Core().V1().Secrets()
+	configMapInformer := factory.Core().V1().ConfigMaps()
+
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName)
+
+	c := &controller{
+		monitorLister:   monitorInformer.Lister(),
+		secretLister:    secretInformer.Lister(),
+		configMapLister: configMapInformer.Lister(),
+		metrics:         m,
+	}
+
+	monitorInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { enqueue(queue, obj) },
+		UpdateFunc: func(_, obj interface{}) { enqueue(queue, obj) },
+		DeleteFunc: c.handleMonitorDelete,
+	})
+
+	// Secrets and ConfigMaps don't carry a back-reference to the
+	// CertificateExpiryMonitors that read them, so any add/update/delete
+	// re-enqueues every monitor. Combined with the informer factory's
+	// periodic resync, this is what makes the collector notice a tracked
+	// certificate being rotated in place, not just a monitor being edited.
+	resourceHandler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { c.enqueueAllMonitors(queue) },
+		UpdateFunc: func(interface{}, interface{}) { c.enqueueAllMonitors(queue) },
+		DeleteFunc: func(interface{}) { c.enqueueAllMonitors(queue) },
+	}
+	secretInformer.Informer().AddEventHandler(resourceHandler)
+	configMapInformer.Informer().AddEventHandler(resourceHandler)
+
+	return c, queue, []cache.InformerSynced{
+		monitorInformer.Informer().HasSynced,
+		secretInformer.Informer().HasSynced,
+		configMapInformer.Informer().HasSynced,
+	}
+}
+
+func enqueue(queue workqueue.RateLimitingInterface, obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	queue.Add(key)
+}
+
+func (c *controller) enqueueAllMonitors(queue workqueue.RateLimitingInterface) {
+	monitors, err := c.monitorLister.List(labels.Everything())
+	if err != nil {
+		return
+	}
+	for _, monitor := range monitors {
+		enqueue(queue, monitor)
+	}
+}
+
+// handleMonitorDelete removes the managed-certificate expiry series for
+// every source of a deleted CertificateExpiryMonitor. This can't be done
+// from ProcessItem, since by the time a deletion reaches the queue the
+// monitor (and its list of sources) is gone from the lister's cache.
+func (c *controller) handleMonitorDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	monitor, ok := obj.(*clustercertsv1alpha1.CertificateExpiryMonitor)
+	if !ok {
+		return
+	}
+	for _, source := range monitor.Spec.Sources {
+		if sourceRef(source) == nil {
+			continue
+		}
+		c.metrics.RemoveManagedCertificateExpiry(monitor.Name, sourceName(source))
+	}
+}
+
+// ProcessItem is the controller's sync function. It re-reads every source
+// listed in the CertificateExpiryMonitor named by key and updates the
+// managed-certificate expiry gauge for each. A source that can't be read
+// or parsed does not stop its siblings in the same monitor from being
+// updated; their errors are aggregated into the returned error so that
+// only the retry/backoff behaviour is affected.
+func (c *controller) ProcessItem(ctx context.Context, key string) error {
+	_, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return fmt.Errorf("invalid resource key: %s", key)
+	}
+
+	monitor, err := c.monitorLister.Get(name)
+	if apierrors.IsNotFound(err) {
+		// Deletion is handled synchronously in handleMonitorDelete, where
+		// the monitor's sources are still available; nothing to do here.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, source := range monitor.Spec.Sources {
+		if err := c.updateSource(monitor.Name, source); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update expiry for source %q: %w", sourceName(source), err))
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+func (c *controller) updateSource(monitorName string, source clustercertsv1alpha1.CertificateExpirySource) error {
+	key := source.Key
+	if key == "" {
+		key = defaultKey
+	}
+
+	data, ref, kind, err := c.readSourceData(source, key)
+	if err != nil {
+		return err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return fmt.Errorf("no PEM data found in %s %s/%s key %q", kind, ref.Namespace, ref.Name, key)
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse certificate in %s %s/%s key %q: %w", kind, ref.Namespace, ref.Name, key, err)
+	}
+
+	c.metrics.UpdateManagedCertificateExpiry(
+		monitorName,
+		ref.Name,
+		ref.Namespace,
+		cert.Subject.String(),
+		cert.Issuer.String(),
+		sourceName(source),
+		cert.NotAfter,
+	)
+
+	return nil
+}
+
+func (c *controller) readSourceData(source clustercertsv1alpha1.CertificateExpirySource, key string) ([]byte, clustercertsv1alpha1.NamespacedObjectReference, string, error) {
+	switch {
+	case source.SecretRef != nil:
+		secret, err := c.secretLister.Secrets(source.SecretRef.Namespace).Get(source.SecretRef.Name)
+		if err != nil {
+			return nil, *source.SecretRef, "Secret", err
+		}
+		return secretData(secret, key), *source.SecretRef, "Secret", nil
+
+	case source.ConfigMapRef != nil:
+		cm, err := c.configMapLister.ConfigMaps(source.ConfigMapRef.Namespace).Get(source.ConfigMapRef.Name)
+		if err != nil {
+			return nil, *source.ConfigMapRef, "ConfigMap", err
+		}
+		return configMapData(cm, key), *source.ConfigMapRef, "ConfigMap", nil
+
+	default:
+		return nil, clustercertsv1alpha1.NamespacedObjectReference{}, "", fmt.Errorf("source must set either secretRef or configMapRef")
+	}
+}
+
+func secretData(secret *corev1.Secret, key string) []byte {
+	if v, ok := secret.Data[key]; ok {
+		return v
+	}
+	return []byte(secret.StringData[key])
+}
+
+func configMapData(cm *corev1.ConfigMap, key string) []byte {
+	if v, ok := cm.BinaryData[key]; ok {
+		return v
+	}
+	return []byte(cm.Data[key])
+}
+
+func sourceRef(source clustercertsv1alpha1.CertificateExpirySource) *clustercertsv1alpha1.NamespacedObjectReference {
+	switch {
+	case source.SecretRef != nil:
+		return source.SecretRef
+	case source.ConfigMapRef != nil:
+		return source.ConfigMapRef
+	default:
+		return nil
+	}
+}
+
+func sourceName(source clustercertsv1alpha1.CertificateExpirySource) string {
+	if source.Name != "" {
+		return source.Name
+	}
+	switch {
+	case source.SecretRef != nil:
+		return fmt.Sprintf("Secret/%s/%s/%s", source.SecretRef.Namespace, source.SecretRef.Name, source.Key)
+	case source.ConfigMapRef != nil:
+		return fmt.Sprintf("ConfigMap/%s/%s/%s", source.ConfigMapRef.Namespace, source.ConfigMapRef.Name, source.Key)
+	default:
+		return "unknown"
+	}
+}
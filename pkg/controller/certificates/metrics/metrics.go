@@ -0,0 +1,103 @@
+/*
+Copyright 2020 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics implements a controller that keeps the Prometheus
+// collectors in pkg/metrics in sync with the state of Certificate
+// resources in the cluster.
+package metrics
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	k8sinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	cminformers "github.com/jetstack/cert-manager/pkg/client/informers/externalversions"
+	cmlisters "github.com/jetstack/cert-manager/pkg/client/listers/certmanager/v1"
+	"github.com/jetstack/cert-manager/pkg/metrics"
+)
+
+const (
+	// ControllerName is the name used to register this controller and to
+	// label its certmanager_controller_sync_call_count series.
+	ControllerName = "certificates-metrics"
+)
+
+// controller updates the Certificate metrics exposed by pkg/metrics in
+// response to Certificate add/update/delete events.
+type controller struct {
+	certificateLister cmlisters.CertificateLister
+	metrics           *metrics.Metrics
+	queue             workqueue.RateLimitingInterface
+}
+
+// NewController returns a new certificate metrics controller, the
+// workqueue it feeds from, and the set of informer sync functions that
+// must return true before it may start processing items.
+func NewController(
+	factory k8sinformers.SharedInformerFactory,
+	cmFactory cminformers.SharedInformerFactory,
+	m *metrics.Metrics,
+) (*controller, workqueue.RateLimitingInterface, []cache.InformerSynced) {
+	certificateInformer := cmFactory.Certmanager().V1().Certificates()
+
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName)
+
+	certificateInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { enqueue(queue, obj) },
+		UpdateFunc: func(_, obj interface{}) { enqueue(queue, obj) },
+		DeleteFunc: func(obj interface{}) { enqueue(queue, obj) },
+	})
+
+	c := &controller{
+		certificateLister: certificateInformer.Lister(),
+		metrics:           m,
+	}
+
+	return c, queue, []cache.InformerSynced{certificateInformer.Informer().HasSynced}
+}
+
+func enqueue(queue workqueue.RateLimitingInterface, obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	queue.Add(key)
+}
+
+// ProcessItem is the controller's sync function: it looks up the
+// Certificate named by key and either updates or removes its metrics.
+func (c *controller) ProcessItem(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return fmt.Errorf("invalid resource key: %s", key)
+	}
+
+	crt, err := c.certificateLister.Certificates(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		c.metrics.RemoveCertificate(key)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	c.metrics.UpdateCertificate(crt)
+	return nil
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2020 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package refresh implements a controller that lets external orchestrators
+// trigger an out-of-band Certificate renewal by adding the
+// "cert-manager.io/refresh-certificate" annotation, observing completion
+// via the "cert-manager.io/refresh-certificate-status" annotation and
+// Kubernetes Events. It is gated behind the AnnotationDrivenRefresh
+// feature.
+package refresh
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	apiutil "github.com/jetstack/cert-manager/pkg/api/util"
+	cmapi "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/jetstack/cert-manager/pkg/apis/meta/v1"
+	cmclient "github.com/jetstack/cert-manager/pkg/client/clientset/versioned"
+	cminformers "github.com/jetstack/cert-manager/pkg/client/informers/externalversions"
+	cmlisters "github.com/jetstack/cert-manager/pkg/client/listers/certmanager/v1"
+)
+
+const (
+	// ControllerName is the name this controller is registered and
+	// recorded against in the controllerpkg sync call counter.
+	ControllerName = "certificates-annotation-refresh"
+
+	reasonRefreshInProgress = "CertificateRefreshInProgress"
+	reasonRefreshDone       = "CertificateRefreshDone"
+	reasonRefreshFailed     = "CertificateRefreshFailed"
+
+	// issuingReasonManualRefresh is the Reason recorded on the Issuing
+	// condition when a reissuance was forced by the refresh annotation,
+	// rather than by the usual renewal-window trigger.
+	issuingReasonManualRefresh = "ManualRefresh"
+)
+
+// controller watches Certificates for the refresh-certificate annotation
+// and drives a forced reissuance, reporting progress back onto the
+// Certificate via annotations and Events.
+type controller struct {
+	certificateLister cmlisters.CertificateLister
+	cmClient          cmclient.Interface
+	kubeClient        kubernetes.Interface
+	recorder          record.EventRecorder
+}
+
+// NewController returns a new annotation-driven refresh controller, the
+// workqueue it feeds from, and the set of informer sync functions that
+// must return true before it may start processing items.
+func NewController(
+	cmFactory cminformers.SharedInformerFactory,
+	cmClient cmclient.Interface,
+	kubeClient kubernetes.Interface,
+	recorder record.EventRecorder,
+) (*controller, workqueue.RateLimitingInterface, []cache.InformerSynced) {
+	certificateInformer := cmFactory.Certmanager().V1().Certificates()
+
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName)
+
+	certificateInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { enqueue(queue, obj) },
+		UpdateFunc: func(_, obj interface{}) { enqueue(queue, obj) },
+	})
+
+	c := &controller{
+		certificateLister: certificateInformer.Lister(),
+		cmClient:          cmClient,
+		kubeClient:        kubeClient,
+		recorder:          recorder,
+	}
+
+	return c, queue, []cache.InformerSynced{certificateInformer.Informer().HasSynced}
+}
+
+func enqueue(queue workqueue.RateLimitingInterface, obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	queue.Add(key)
+}
+
+// ProcessItem is the controller's sync function. It either starts a
+// requested refresh, or checks on the progress of one already underway.
+func (c *controller) ProcessItem(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return fmt.Errorf("invalid resource key: %s", key)
+	}
+
+	crt, err := c.certificateLister.Certificates(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	requestedRefresh, requested := crt.Annotations[cmapi.RefreshCertificateAnnotationKey]
+	if !requested || requestedRefresh == "" {
+		return nil
+	}
+
+	status := cmapi.RefreshCertificateStatus(crt.Annotations[cmapi.RefreshCertificateStatusAnnotationKey])
+	if status == cmapi.RefreshCertificateStatusInProgress {
+		return c.checkProgress(ctx, crt)
+	}
+
+	return c.startRefresh(ctx, crt, requestedRefresh)
+}
+
+// startRefresh forces a reissuance by setting the Issuing condition, and
+// records that a refresh is now in progress.
+func (c *controller) startRefresh(ctx context.Context, crt *cmapi.Certificate, requestedRefresh string) error {
+	crt = crt.DeepCopy()
+
+	message := "Certificate reissuance forced by the refresh-certificate annotation"
+	if requestedRefresh == cmapi.RefreshCertificateNewPrivateKeyAnnotationValue {
+		message += ", with a new private key"
+
+		// The old key must be gone before we flip Issuing to True: the
+		// issuing controller reacts to that condition asynchronously, and
+		// could otherwise read the Certificate and reuse the still-present
+		// Secret's key before we get around to deleting it.
+		if err := c.kubeClient.CoreV1().Secrets(crt.Namespace).Delete(ctx, crt.Spec.SecretName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	apiutil.SetCertificateCondition(crt, cmapi.CertificateConditionIssuing, cmmeta.ConditionTrue, issuingReasonManualRefresh, message)
+
+	// UpdateStatus returns the Certificate with its resourceVersion bumped;
+	// the follow-up Update of the annotations below must start from that
+	// object, or it will always lose to the status update with a 409
+	// conflict.
+	updated, err := c.cmClient.CertmanagerV1().Certificates(crt.Namespace).UpdateStatus(ctx, crt, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+
+	updated.Annotations[cmapi.RefreshCertificateStatusAnnotationKey] = string(cmapi.RefreshCertificateStatusInProgress)
+	if _, err := c.cmClient.CertmanagerV1().Certificates(updated.Namespace).Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+
+	c.recorder.Event(updated, corev1.EventTypeNormal, reasonRefreshInProgress, message)
+	return nil
+}
+
+// checkProgress inspects the Certificate's Issuing/Ready conditions to
+// decide whether an in-progress refresh has completed, and if so reports
+// the outcome and clears the trigger annotation.
+func (c *controller) checkProgress(ctx context.Context, crt *cmapi.Certificate) error {
+	issuing := apiutil.GetCertificateCondition(crt, cmapi.CertificateConditionIssuing)
+	if issuing != nil && issuing.Status == cmmeta.ConditionTrue {
+		// Reissuance is still underway.
+		return nil
+	}
+
+	ready := apiutil.GetCertificateCondition(crt, cmapi.CertificateConditionReady)
+
+	crt = crt.DeepCopy()
+	delete(crt.Annotations, cmapi.RefreshCertificateAnnotationKey)
+
+	if ready != nil && ready.Status == cmmeta.ConditionTrue {
+		crt.Annotations[cmapi.RefreshCertificateStatusAnnotationKey] = string(cmapi.RefreshCertificateStatusDone)
+		if _, err := c.cmClient.CertmanagerV1().Certificates(crt.Namespace).Update(ctx, crt, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		c.recorder.Event(crt, corev1.EventTypeNormal, reasonRefreshDone, "Certificate refresh completed successfully")
+		return nil
+	}
+
+	crt.Annotations[cmapi.RefreshCertificateStatusAnnotationKey] = string(cmapi.RefreshCertificateStatusFailed)
+	if _, err := c.cmClient.CertmanagerV1().Certificates(crt.Namespace).Update(ctx, crt, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	reason := "unknown error"
+	if ready != nil {
+		reason = ready.Message
+	}
+	c.recorder.Eventf(crt, corev1.EventTypeWarning, reasonRefreshFailed, "Certificate refresh failed: %s", reason)
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2020 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package controller provides a small generic work-queue driven controller
+// runner that is shared by all cert-manager controllers.
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/jetstack/cert-manager/pkg/metrics"
+)
+
+// syncFunc is the work function invoked for every item popped off a
+// controller's queue.
+type syncFunc func(ctx context.Context, key string) error
+
+// Controller drains a workqueue, invoking a sync function for each item and
+// recording how many sync calls each named controller makes.
+type Controller struct {
+	name     string
+	metrics  *metrics.Metrics
+	syncFunc syncFunc
+	mustSync []cache.InformerSynced
+	queue    workqueue.RateLimitingInterface
+}
+
+// NewController returns a new generic Controller. mustSync is a list of
+// InformerSynced-style functions that must all return true before the
+// controller starts processing items off queue.
+func NewController(
+	ctx context.Context,
+	name string,
+	metrics *metrics.Metrics,
+	syncFunc syncFunc,
+	mustSync []cache.InformerSynced,
+	_ []func(),
+	queue workqueue.RateLimitingInterface,
+) *Controller {
+	return &Controller{
+		name:     name,
+		metrics:  metrics,
+		syncFunc: syncFunc,
+		mustSync: mustSync,
+		queue:    queue,
+	}
+}
+
+// Run starts processing items from the controller's queue until ctx is
+// cancelled.
+func (c *Controller) Run(ctx context.Context, workers int) error {
+	defer utilruntime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	if !cache.WaitForCacheSync(ctx.Done(), c.mustSync...) {
+		return fmt.Errorf("error waiting for informer caches to sync")
+	}
+
+	for i := 0; i < workers; i++ {
+		go c.worker(ctx)
+	}
+
+	<-ctx.Done()
+	return nil
+}
+
+func (c *Controller) worker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	key, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	if c.metrics != nil {
+		c.metrics.IncrementSyncCallCount(c.name)
+	}
+
+	if err := c.syncFunc(ctx, key.(string)); err != nil {
+		utilruntime.HandleError(err)
+		c.queue.AddRateLimited(key)
+		return true
+	}
+
+	c.queue.Forget(key)
+	return true
+}
+
+// NewQueue builds a standard rate-limited workqueue for a named controller.
+func NewQueue(name string) workqueue.RateLimitingInterface {
+	return workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), name)
+}
+
+// RetryAfter is a fixed requeue delay of five seconds. Controller does not
+// use it: an item whose sync fails is requeued through the queue's rate
+// limiter instead.
+const RetryAfter = time.Second * 5
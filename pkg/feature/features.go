@@ -40,6 +40,29 @@ const (
 	// ExperimentalGatewayAPISupport enables the gateway-shim controller and adds support for
 	// the Gateway API to the HTTP-01 challenge solver.
 	ExperimentalGatewayAPISupport featuregate.Feature = "ExperimentalGatewayAPISupport"
+
+	// alpha: v1.6.0
+	//
+	// AnnotationDrivenRefresh enables the annotation-driven refresh controller, which
+	// forces an out-of-band renewal of a Certificate when the
+	// "cert-manager.io/refresh-certificate" annotation is added to it, reporting
+	// completion back via the "cert-manager.io/refresh-certificate-status" annotation.
+	AnnotationDrivenRefresh featuregate.Feature = "AnnotationDrivenRefresh"
+
+	// alpha: v1.6.0
+	//
+	// ExternalSignerPlugins enables the "ExternalPlugin" issuer type, which
+	// delegates signing of CertificateRequests to an external binary or to a
+	// plugin listening on a Unix domain socket (JSON over the socket, not gRPC).
+	ExternalSignerPlugins featuregate.Feature = "ExternalSignerPlugins"
+
+	// alpha: v1.6.0
+	//
+	// ClusterCertificateExpiryMonitor enables the cluster certificate expiry
+	// collector, which periodically parses X.509 material referenced by
+	// CertificateExpiryMonitor resources and exposes its expiry even when it
+	// was not issued by cert-manager.
+	ClusterCertificateExpiryMonitor featuregate.Feature = "ClusterCertificateExpiryMonitor"
 )
 
 func init() {
@@ -53,4 +76,7 @@ var defaultCertManagerFeatureGates = map[featuregate.Feature]featuregate.Feature
 	ValidateCAA: {Default: false, PreRelease: featuregate.Alpha},
 	ExperimentalCertificateSigningRequestControllers: {Default: false, PreRelease: featuregate.Alpha},
 	ExperimentalGatewayAPISupport:                    {Default: false, PreRelease: featuregate.Alpha},
+	AnnotationDrivenRefresh:                          {Default: false, PreRelease: featuregate.Alpha},
+	ExternalSignerPlugins:                            {Default: false, PreRelease: featuregate.Alpha},
+	ClusterCertificateExpiryMonitor:                  {Default: false, PreRelease: featuregate.Alpha},
 }
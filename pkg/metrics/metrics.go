@@ -0,0 +1,417 @@
+/*
+Copyright 2020 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics exposes a Prometheus registry and the gauges/counters
+// that cert-manager controllers update as they observe Certificate state.
+package metrics
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/utils/clock"
+
+	cmapi "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1"
+)
+
+const (
+	namespaceLabel  = "namespace"
+	nameLabel       = "name"
+	conditionLabel  = "condition"
+	controllerLabel = "controller"
+
+	issuerNameLabel  = "issuer_name"
+	issuerKindLabel  = "issuer_kind"
+	issuerGroupLabel = "issuer_group"
+	reasonLabel      = "reason"
+	bucketLabel      = "bucket"
+
+	pluginLabel = "plugin"
+	resultLabel = "result"
+
+	subjectLabel = "subject"
+	issuerLabel  = "issuer"
+	sourceLabel  = "source"
+	monitorLabel = "monitor"
+)
+
+// Expiry buckets used to classify a Certificate's time-to-expiry into a
+// coarse, alertable category.
+const (
+	BucketExpired = "expired"
+	BucketLt7d    = "<7d"
+	BucketLt30d   = "<30d"
+	BucketLt90d   = "<90d"
+	BucketOK      = "ok"
+)
+
+// allBuckets is the ordered set of every bucket label value, used to zero
+// out buckets a Certificate is no longer in.
+var allBuckets = []string{BucketExpired, BucketLt7d, BucketLt30d, BucketLt90d, BucketOK}
+
+// Metrics wraps a Prometheus registry and the set of collectors that
+// cert-manager controllers update as they reconcile resources.
+type Metrics struct {
+	log   logr.Logger
+	clock clock.Clock
+
+	registry *prometheus.Registry
+
+	clockTimeSeconds prometheus.CounterFunc
+
+	certificateExpiryTimeSeconds   *prometheus.GaugeVec
+	certificateTimeToExpirySeconds *prometheus.GaugeVec
+	certificateReadyStatus         *prometheus.GaugeVec
+	certificateRenewalTimeSeconds  *prometheus.GaugeVec
+	certificateExpiryBucket        *prometheus.GaugeVec
+	certificateExpiryBucketTotal   *prometheus.CounterVec
+
+	externalSignerCallsTotal *prometheus.CounterVec
+
+	managedCertificateExpiryTimeSeconds *prometheus.GaugeVec
+
+	controllerSyncCallCount *prometheus.CounterVec
+
+	// bucketMu guards lastBucketSeen, which certificateExpiryBucketTotal is
+	// incremented from only on transition, rather than on every sync.
+	bucketMu       sync.Mutex
+	lastBucketSeen map[string]string
+}
+
+// New creates a Metrics instance with all collectors registered against a
+// fresh, private Prometheus registry.
+func New(log logr.Logger, c clock.Clock) *Metrics {
+	m := &Metrics{
+		log:   log.WithName("metrics"),
+		clock: c,
+
+		registry: prometheus.NewRegistry(),
+
+		lastBucketSeen: make(map[string]string),
+
+		clockTimeSeconds: prometheus.NewCounterFunc(
+			prometheus.CounterOpts{
+				Name: "certmanager_clock_time_seconds",
+				Help: "The clock time given in seconds (from 1970/01/01 UTC).",
+			},
+			func() float64 { return float64(c.Now().Unix()) },
+		),
+
+		certificateExpiryTimeSeconds: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "certmanager_certificate_expiration_timestamp_seconds",
+				Help: "The date after which the certificate expires. Expressed as a Unix Epoch Time.",
+			},
+			[]string{nameLabel, namespaceLabel},
+		),
+
+		certificateTimeToExpirySeconds: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "certmanager_certificate_time_to_expiry_seconds",
+				Help: "The number of seconds before a certificate's notAfter expiry, relative to the controller's clock. Negative once the certificate has expired.",
+			},
+			[]string{nameLabel, namespaceLabel},
+		),
+
+		certificateReadyStatus: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "certmanager_certificate_ready_status",
+				Help: "The ready status of the certificate.",
+			},
+			[]string{conditionLabel, nameLabel, namespaceLabel, issuerNameLabel, issuerKindLabel, issuerGroupLabel, reasonLabel},
+		),
+
+		certificateRenewalTimeSeconds: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "certmanager_certificate_renewal_timestamp_seconds",
+				Help: "The time after which the certificate is scheduled for renewal. Expressed as a Unix Epoch Time.",
+			},
+			[]string{nameLabel, namespaceLabel},
+		),
+
+		certificateExpiryBucket: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "certmanager_certificate_expiry_bucket",
+				Help: "Indicates, with a value of 1, which expiry bucket a certificate currently falls into: expired, <7d, <30d, <90d or ok.",
+			},
+			[]string{nameLabel, namespaceLabel, bucketLabel},
+		),
+
+		certificateExpiryBucketTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "certmanager_certificate_expiry_bucket_total",
+				Help: "The total number of times a certificate, aggregated per issuer, has transitioned into an expiry bucket.",
+			},
+			[]string{issuerNameLabel, issuerKindLabel, issuerGroupLabel, bucketLabel},
+		),
+
+		externalSignerCallsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "certmanager_external_signer_calls_total",
+				Help: "The number of calls made to external signer plugins, by plugin and result.",
+			},
+			[]string{pluginLabel, resultLabel},
+		),
+
+		managedCertificateExpiryTimeSeconds: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "certmanager_managed_certificate_expiration_timestamp_seconds",
+				Help: "The date after which a certificate tracked by a CertificateExpiryMonitor, but not necessarily issued by cert-manager, expires. Expressed as a Unix Epoch Time.",
+			},
+			[]string{monitorLabel, nameLabel, namespaceLabel, subjectLabel, issuerLabel, sourceLabel},
+		),
+
+		controllerSyncCallCount: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "certmanager_controller_sync_call_count",
+				Help: "The number of sync() calls made by a controller.",
+			},
+			[]string{controllerLabel},
+		),
+	}
+
+	m.registry.MustRegister(
+		m.clockTimeSeconds,
+		m.certificateExpiryTimeSeconds,
+		m.certificateTimeToExpirySeconds,
+		m.certificateReadyStatus,
+		m.certificateRenewalTimeSeconds,
+		m.certificateExpiryBucket,
+		m.certificateExpiryBucketTotal,
+		m.externalSignerCallsTotal,
+		m.managedCertificateExpiryTimeSeconds,
+		m.controllerSyncCallCount,
+	)
+
+	return m
+}
+
+// NewServer returns an http.Server that serves the /metrics endpoint for
+// this Metrics instance, optionally over TLS.
+func (m *Metrics) NewServer(ln net.Listener, useTLS bool) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{ErrorLog: nil}))
+
+	server := &http.Server{
+		Handler: mux,
+		Addr:    ln.Addr().String(),
+	}
+	if useTLS {
+		server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return server
+}
+
+// IncrementSyncCallCount increments the sync call counter for the named
+// controller.
+func (m *Metrics) IncrementSyncCallCount(controllerName string) {
+	m.controllerSyncCallCount.WithLabelValues(controllerName).Inc()
+}
+
+// External signer plugin call results, used as the "result" label on
+// certmanager_external_signer_calls_total.
+const (
+	ExternalSignerResultSuccess = "success"
+	ExternalSignerResultError   = "error"
+	ExternalSignerResultTimeout = "timeout"
+)
+
+// IncrementExternalSignerCalls records a single call to an external signer
+// plugin and its outcome.
+func (m *Metrics) IncrementExternalSignerCalls(plugin, result string) {
+	m.externalSignerCallsTotal.WithLabelValues(plugin, result).Inc()
+}
+
+// UpdateManagedCertificateExpiry records the expiry of an X.509
+// certificate discovered by the cluster certificate expiry collector,
+// identified by the CertificateExpiryMonitor that configured it and the
+// name/namespace of the source it was read from.
+func (m *Metrics) UpdateManagedCertificateExpiry(monitor, name, namespace, subject, issuer, source string, notAfter time.Time) {
+	m.managedCertificateExpiryTimeSeconds.WithLabelValues(monitor, name, namespace, subject, issuer, source).Set(float64(notAfter.Unix()))
+}
+
+// RemoveManagedCertificateExpiry removes the expiry series for a single
+// source of monitor. The cluster certificate expiry controller calls it
+// only when a CertificateExpiryMonitor is deleted; the series of a source
+// that is dropped from a monitor's spec, or that can no longer be read, is
+// left in place. Matching on monitor and source, rather than just the target
+// object's name/namespace, means two CertificateExpiryMonitors (or two
+// sources) referencing the same Secret or ConfigMap don't clobber each
+// other's series.
+func (m *Metrics) RemoveManagedCertificateExpiry(monitor, source string) {
+	m.managedCertificateExpiryTimeSeconds.DeletePartialMatch(prometheus.Labels{monitorLabel: monitor, sourceLabel: source})
+}
+
+// expiryBucket classifies a time-to-expiry duration into one of the coarse
+// alertable buckets.
+func expiryBucket(ttl time.Duration) string {
+	switch {
+	case ttl <= 0:
+		return BucketExpired
+	case ttl < 7*24*time.Hour:
+		return BucketLt7d
+	case ttl < 30*24*time.Hour:
+		return BucketLt30d
+	case ttl < 90*24*time.Hour:
+		return BucketLt90d
+	default:
+		return BucketOK
+	}
+}
+
+// UpdateCertificate updates the expiry, ready-status, renewal and bucket
+// gauges for the given Certificate.
+func (m *Metrics) UpdateCertificate(crt *cmapi.Certificate) {
+	m.updateCertificateExpiry(crt)
+	m.updateCertificateStatus(crt)
+	m.updateCertificateRenewalTime(crt)
+	m.updateCertificateExpiryBucket(crt)
+}
+
+func (m *Metrics) updateCertificateExpiry(crt *cmapi.Certificate) {
+	if crt.Status.NotAfter != nil {
+		m.certificateExpiryTimeSeconds.WithLabelValues(crt.Name, crt.Namespace).Set(float64(crt.Status.NotAfter.Unix()))
+		ttl := crt.Status.NotAfter.Time.Sub(m.clock.Now())
+		m.certificateTimeToExpirySeconds.WithLabelValues(crt.Name, crt.Namespace).Set(ttl.Seconds())
+	} else {
+		m.certificateExpiryTimeSeconds.WithLabelValues(crt.Name, crt.Namespace).Set(0)
+		m.certificateTimeToExpirySeconds.WithLabelValues(crt.Name, crt.Namespace).Set(0)
+	}
+}
+
+func (m *Metrics) updateCertificateRenewalTime(crt *cmapi.Certificate) {
+	if crt.Status.RenewalTime != nil {
+		m.certificateRenewalTimeSeconds.WithLabelValues(crt.Name, crt.Namespace).Set(float64(crt.Status.RenewalTime.Unix()))
+	} else {
+		m.certificateRenewalTimeSeconds.WithLabelValues(crt.Name, crt.Namespace).Set(0)
+	}
+}
+
+func (m *Metrics) updateCertificateExpiryBucket(crt *cmapi.Certificate) {
+	bucket := BucketOK
+	if crt.Status.NotAfter != nil {
+		bucket = expiryBucket(crt.Status.NotAfter.Time.Sub(m.clock.Now()))
+	}
+
+	for _, b := range allBuckets {
+		value := 0.0
+		if b == bucket {
+			value = 1
+		}
+		m.certificateExpiryBucket.WithLabelValues(crt.Name, crt.Namespace, b).Set(value)
+	}
+
+	if m.recordBucketTransition(crt.Namespace, crt.Name, bucket) {
+		m.certificateExpiryBucketTotal.WithLabelValues(
+			crt.Spec.IssuerRef.Name,
+			crt.Spec.IssuerRef.Kind,
+			crt.Spec.IssuerRef.Group,
+			bucket,
+		).Inc()
+	}
+}
+
+// recordBucketTransition records bucket as the most recently observed
+// expiry bucket for the Certificate identified by namespace/name, and
+// reports whether that is a change from what was last observed. This is
+// what makes certmanager_certificate_expiry_bucket_total count bucket
+// transitions rather than sync calls, since ProcessItem also runs on the
+// informer's periodic resync and on unrelated updates.
+func (m *Metrics) recordBucketTransition(namespace, name, bucket string) bool {
+	key := joinKey(namespace, name)
+
+	m.bucketMu.Lock()
+	defer m.bucketMu.Unlock()
+
+	if m.lastBucketSeen[key] == bucket {
+		return false
+	}
+	m.lastBucketSeen[key] = bucket
+	return true
+}
+
+func (m *Metrics) updateCertificateStatus(crt *cmapi.Certificate) {
+	status, reason := certificateReadyStatusAndReason(crt)
+
+	// The issuer and reason labels can change between syncs (e.g. a
+	// Certificate is re-issued by a different Issuer, or its Ready reason
+	// changes). Delete the previous label combination first so stale
+	// series don't accumulate alongside the current one.
+	m.certificateReadyStatus.DeletePartialMatch(prometheus.Labels{nameLabel: crt.Name, namespaceLabel: crt.Namespace})
+
+	for _, s := range []string{"True", "False", "Unknown"} {
+		value := 0.0
+		if status == s {
+			value = 1
+		}
+		m.certificateReadyStatus.WithLabelValues(
+			s,
+			crt.Name,
+			crt.Namespace,
+			crt.Spec.IssuerRef.Name,
+			crt.Spec.IssuerRef.Kind,
+			crt.Spec.IssuerRef.Group,
+			reason,
+		).Set(value)
+	}
+}
+
+// certificateReadyStatusAndReason returns the status and reason of a
+// Certificate's latest Ready condition, defaulting to "Unknown"/"" if no
+// such condition has been set yet.
+func certificateReadyStatusAndReason(crt *cmapi.Certificate) (status, reason string) {
+	for _, c := range crt.Status.Conditions {
+		if c.Type == cmapi.CertificateConditionReady {
+			return string(c.Status), c.Reason
+		}
+	}
+	return "Unknown", ""
+}
+
+// RemoveCertificate removes all metrics series associated with the
+// Certificate identified by key (in namespace/name form).
+func (m *Metrics) RemoveCertificate(key string) {
+	namespace, name := splitKey(key)
+
+	m.certificateExpiryTimeSeconds.DeletePartialMatch(prometheus.Labels{nameLabel: name, namespaceLabel: namespace})
+	m.certificateTimeToExpirySeconds.DeletePartialMatch(prometheus.Labels{nameLabel: name, namespaceLabel: namespace})
+	m.certificateReadyStatus.DeletePartialMatch(prometheus.Labels{nameLabel: name, namespaceLabel: namespace})
+	m.certificateRenewalTimeSeconds.DeletePartialMatch(prometheus.Labels{nameLabel: name, namespaceLabel: namespace})
+	m.certificateExpiryBucket.DeletePartialMatch(prometheus.Labels{nameLabel: name, namespaceLabel: namespace})
+
+	m.bucketMu.Lock()
+	delete(m.lastBucketSeen, joinKey(namespace, name))
+	m.bucketMu.Unlock()
+}
+
+func splitKey(key string) (namespace, name string) {
+	for i := 0; i < len(key); i++ {
+		if key[i] == '/' {
+			return key[:i], key[i+1:]
+		}
+	}
+	return "", key
+}
+
+func joinKey(namespace, name string) string {
+	return namespace + "/" + name
+}
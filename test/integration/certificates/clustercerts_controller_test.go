@@ -0,0 +1,192 @@
+/*
+Copyright 2020 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificates
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	clustercertsv1alpha1 "github.com/jetstack/cert-manager/pkg/apis/clustercerts/v1alpha1"
+	controllerpkg "github.com/jetstack/cert-manager/pkg/controller"
+	"github.com/jetstack/cert-manager/pkg/controller/certificates/clustercerts"
+	logf "github.com/jetstack/cert-manager/pkg/logs"
+	"github.com/jetstack/cert-manager/pkg/metrics"
+	"github.com/jetstack/cert-manager/test/integration/framework"
+)
+
+// selfSignedCertPEM returns a freshly generated, self-signed certificate
+// for commonName expiring at notAfter, PEM encoded.
+func selfSignedCertPEM(t *testing.T, commonName string, notAfter time.Time) []byte {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// TestClusterCertsController exercises the cluster certificate expiry
+// collector end to end: a Secret referenced by a CertificateExpiryMonitor
+// should produce a managed-certificate expiry series scoped to that
+// monitor, and deleting the monitor should remove it again.
+func TestClusterCertsController(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*40)
+	defer cancel()
+
+	config, stopFn := framework.RunControlPlane(t, ctx)
+	defer stopFn()
+
+	kubeClient, factory, cmClient, cmFactory := framework.NewClients(t, config)
+
+	metricsHandler := metrics.New(logf.Log, fixedClock)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := metricsHandler.NewServer(ln, false)
+
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		if err := server.Serve(ln); err != http.ErrServerClosed {
+			t.Fatal(err)
+		}
+	}()
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			t.Fatal(err)
+		}
+		<-doneCh
+	}()
+
+	ctrl, queue, mustSync := clustercerts.NewController(factory, cmFactory, metricsHandler)
+	c := controllerpkg.NewController(
+		ctx,
+		"clustercerts_test",
+		metricsHandler,
+		ctrl.ProcessItem,
+		mustSync,
+		nil,
+		queue,
+	)
+	stopController := framework.StartInformersAndController(t, factory, cmFactory, c)
+	defer stopController()
+
+	const (
+		namespace   = "testns"
+		secretName  = "apiserver-cert"
+		monitorName = "apiserver"
+	)
+	metricsEndpoint := fmt.Sprintf("http://%s/metrics", server.Addr)
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+	if _, err := kubeClient.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	notAfter := fixedClock.Now().Add(time.Hour * 24 * 30)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: namespace},
+		Data:       map[string][]byte{"tls.crt": selfSignedCertPEM(t, "kube-apiserver", notAfter)},
+	}
+	if _, err := kubeClient.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	monitor := &clustercertsv1alpha1.CertificateExpiryMonitor{
+		ObjectMeta: metav1.ObjectMeta{Name: monitorName},
+		Spec: clustercertsv1alpha1.CertificateExpiryMonitorSpec{
+			Sources: []clustercertsv1alpha1.CertificateExpirySource{
+				{
+					SecretRef: &clustercertsv1alpha1.NamespacedObjectReference{Name: secretName, Namespace: namespace},
+				},
+			},
+		},
+	}
+	if _, err := cmClient.ClustercertsV1alpha1().CertificateExpiryMonitors().Create(ctx, monitor, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	wantLabels := map[string]string{
+		"monitor":   monitorName,
+		"name":      secretName,
+		"namespace": namespace,
+		"subject":   "CN=kube-apiserver",
+		"issuer":    "CN=kube-apiserver",
+		"source":    fmt.Sprintf("Secret/%s/%s/", namespace, secretName),
+	}
+
+	err = wait.PollImmediateUntil(time.Millisecond*100, func() (bool, error) {
+		mfs := framework.FetchMetrics(t, metricsEndpoint)
+		mf, ok := mfs["certmanager_managed_certificate_expiration_timestamp_seconds"]
+		return ok && len(mf.Metric) > 0, nil
+	}, ctx.Done())
+	if err != nil {
+		t.Fatalf("timed out waiting for managed certificate expiry series to appear: %v", err)
+	}
+
+	mfs := framework.FetchMetrics(t, metricsEndpoint)
+	framework.AssertGauge(t, mfs, "certmanager_managed_certificate_expiration_timestamp_seconds", wantLabels, float64(notAfter.Unix()))
+
+	if err := cmClient.ClustercertsV1alpha1().CertificateExpiryMonitors().Delete(ctx, monitorName, metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	err = wait.PollImmediateUntil(time.Millisecond*100, func() (bool, error) {
+		mfs := framework.FetchMetrics(t, metricsEndpoint)
+		mf, ok := mfs["certmanager_managed_certificate_expiration_timestamp_seconds"]
+		return !ok || len(mf.Metric) == 0, nil
+	}, ctx.Done())
+	if err != nil {
+		t.Fatalf("timed out waiting for managed certificate expiry series to disappear: %v", err)
+	}
+
+	mfs = framework.FetchMetrics(t, metricsEndpoint)
+	framework.AssertAbsent(t, mfs, "certmanager_managed_certificate_expiration_timestamp_seconds", wantLabels)
+}
@@ -19,10 +19,8 @@ package certificates
 import (
 	"context"
 	"fmt"
-	"io"
 	"net"
 	"net/http"
-	"strings"
 	"testing"
 	"time"
 
@@ -42,12 +40,23 @@ import (
 	"github.com/jetstack/cert-manager/test/unit/gen"
 )
 
-var (
-	fixedClock  = fakeclock.NewFakeClock(time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC))
-	clockMetric = fmt.Sprintf(`# HELP certmanager_clock_time_seconds The clock time given in seconds (from 1970/01/01 UTC).
-# TYPE certmanager_clock_time_seconds counter
-certmanager_clock_time_seconds %.9e`, float64(fixedClock.Now().Unix()))
-)
+var fixedClock = fakeclock.NewFakeClock(time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC))
+
+func readyStatusLabels(name, namespace, condition, issuerName, issuerKind, reason string) map[string]string {
+	return map[string]string{
+		"condition":    condition,
+		"name":         name,
+		"namespace":    namespace,
+		"issuer_name":  issuerName,
+		"issuer_kind":  issuerKind,
+		"issuer_group": "",
+		"reason":       reason,
+	}
+}
+
+func nameNamespaceLabels(name, namespace string) map[string]string {
+	return map[string]string{"name": name, "namespace": namespace}
+}
 
 // TestMetricscontoller performs a basic test to ensure that Certificates
 // metrics are exposed when a Certificate is created, updated, and removed when
@@ -104,8 +113,6 @@ func TestMetricsController(t *testing.T) {
 		crtName         = "testcrt"
 		namespace       = "testns"
 		metricsEndpoint = fmt.Sprintf("http://%s/metrics", server.Addr)
-
-		lastErr error
 	)
 
 	// Create Namespace
@@ -115,41 +122,29 @@ func TestMetricsController(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	testMetrics := func(expectedOutput string) error {
-		resp, err := http.DefaultClient.Get(metricsEndpoint)
-		if err != nil {
-			return err
-		}
-
-		output, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return err
-		}
-
-		if strings.TrimSpace(string(output)) != strings.TrimSpace(expectedOutput) {
-			return fmt.Errorf("got unexpected metrics output\nexp:\n%s\ngot:\n%s\n",
-				expectedOutput, output)
-		}
-
-		return nil
-	}
-
-	waitForMetrics := func(expectedOutput string) {
-		err := wait.PollImmediateUntil(time.Millisecond*100, func() (done bool, err error) {
-			if err := testMetrics(expectedOutput); err != nil {
-				lastErr = err
+	waitForSync := func(wantCount float64) {
+		err := wait.PollImmediateUntil(time.Millisecond*100, func() (bool, error) {
+			mfs := framework.FetchMetrics(t, metricsEndpoint)
+			mf, ok := mfs["certmanager_controller_sync_call_count"]
+			if !ok {
 				return false, nil
 			}
-
-			return true, nil
+			for _, m := range mf.Metric {
+				if m.Counter != nil && m.Counter.GetValue() == wantCount {
+					return true, nil
+				}
+			}
+			return false, nil
 		}, ctx.Done())
 		if err != nil {
-			t.Fatalf("%s: failed to wait for expected metrics to be exposed: %s", err, lastErr)
+			t.Fatalf("timed out waiting for sync call count to reach %v: %v", wantCount, err)
 		}
 	}
 
-	// Should expose no additional metrics
-	waitForMetrics(clockMetric)
+	// Should expose no Certificate-related series yet.
+	mfs := framework.FetchMetrics(t, metricsEndpoint)
+	framework.AssertAbsent(t, mfs, "certmanager_certificate_expiration_timestamp_seconds", nil)
+	framework.AssertAbsent(t, mfs, "certmanager_certificate_ready_status", nil)
 
 	// Create Certificate
 	crt := gen.Certificate(crtName,
@@ -165,60 +160,63 @@ func TestMetricsController(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	// Should expose that Certificate as unknown with no expiry
-	waitForMetrics(`# HELP certmanager_certificate_expiration_timestamp_seconds The date after which the certificate expires. Expressed as a Unix Epoch Time.
-# TYPE certmanager_certificate_expiration_timestamp_seconds gauge
-certmanager_certificate_expiration_timestamp_seconds{name="testcrt",namespace="testns"} 0
-# HELP certmanager_certificate_ready_status The ready status of the certificate.
-# TYPE certmanager_certificate_ready_status gauge
-certmanager_certificate_ready_status{condition="False",name="testcrt",namespace="testns"} 0
-certmanager_certificate_ready_status{condition="True",name="testcrt",namespace="testns"} 0
-certmanager_certificate_ready_status{condition="Unknown",name="testcrt",namespace="testns"} 1
-` + clockMetric + `
-# HELP certmanager_controller_sync_call_count The number of sync() calls made by a controller.
-# TYPE certmanager_controller_sync_call_count counter
-certmanager_controller_sync_call_count{controller="metrics_test"} 1
-`)
-
-	// Set Certificate Expiry and Ready status True
-	crt.Status.NotAfter = &metav1.Time{
-		Time: time.Unix(100, 0),
-	}
+	waitForSync(1)
+	mfs = framework.FetchMetrics(t, metricsEndpoint)
+
+	framework.AssertGauge(t, mfs, "certmanager_certificate_expiration_timestamp_seconds", nameNamespaceLabels(crtName, namespace), 0)
+	framework.AssertGauge(t, mfs, "certmanager_certificate_time_to_expiry_seconds", nameNamespaceLabels(crtName, namespace), 0)
+	framework.AssertGauge(t, mfs, "certmanager_certificate_ready_status", readyStatusLabels(crtName, namespace, "Unknown", "test-issuer", "Issuer", ""), 1)
+	framework.AssertGauge(t, mfs, "certmanager_certificate_ready_status", readyStatusLabels(crtName, namespace, "True", "test-issuer", "Issuer", ""), 0)
+	framework.AssertGauge(t, mfs, "certmanager_certificate_expiry_bucket", map[string]string{"name": crtName, "namespace": namespace, "bucket": "ok"}, 1)
+
+	// Set Certificate Expiry to 4 days from the fixed clock (into the <7d
+	// bucket) and Ready status True.
+	crt.Status.NotAfter = &metav1.Time{Time: fixedClock.Now().Add(time.Hour * 24 * 4)}
 	crt.Status.Conditions = []cmapi.CertificateCondition{
 		{
 			Type:   cmapi.CertificateConditionReady,
 			Status: cmmeta.ConditionTrue,
+			Reason: "Ready",
 		},
 	}
+	crt.Status.RenewalTime = &metav1.Time{Time: crt.Status.NotAfter.Time.Add(-time.Hour)}
+	crt, err = cmClient.CertmanagerV1().Certificates(namespace).UpdateStatus(ctx, crt, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waitForSync(2)
+	mfs = framework.FetchMetrics(t, metricsEndpoint)
+
+	framework.AssertGauge(t, mfs, "certmanager_certificate_expiration_timestamp_seconds", nameNamespaceLabels(crtName, namespace), float64(crt.Status.NotAfter.Unix()))
+	framework.AssertGauge(t, mfs, "certmanager_certificate_renewal_timestamp_seconds", nameNamespaceLabels(crtName, namespace), float64(crt.Status.RenewalTime.Unix()))
+	framework.AssertGauge(t, mfs, "certmanager_certificate_ready_status", readyStatusLabels(crtName, namespace, "True", "test-issuer", "Issuer", "Ready"), 1)
+	framework.AssertGauge(t, mfs, "certmanager_certificate_expiry_bucket", map[string]string{"name": crtName, "namespace": namespace, "bucket": "<7d"}, 1)
+	framework.AssertGauge(t, mfs, "certmanager_certificate_expiry_bucket", map[string]string{"name": crtName, "namespace": namespace, "bucket": "ok"}, 0)
+	framework.AssertCounter(t, mfs, "certmanager_certificate_expiry_bucket_total", map[string]string{"issuer_name": "test-issuer", "issuer_kind": "Issuer", "issuer_group": "", "bucket": "<7d"}, 1)
+
+	// Move the expiry to the past, crossing the "expired" threshold.
+	crt.Status.NotAfter = &metav1.Time{Time: fixedClock.Now().Add(-time.Hour)}
 	_, err = cmClient.CertmanagerV1().Certificates(namespace).UpdateStatus(ctx, crt, metav1.UpdateOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	// Should expose that Certificate as ready with expiry
-	waitForMetrics(`# HELP certmanager_certificate_expiration_timestamp_seconds The date after which the certificate expires. Expressed as a Unix Epoch Time.
-# TYPE certmanager_certificate_expiration_timestamp_seconds gauge
-certmanager_certificate_expiration_timestamp_seconds{name="testcrt",namespace="testns"} 100
-# HELP certmanager_certificate_ready_status The ready status of the certificate.
-# TYPE certmanager_certificate_ready_status gauge
-certmanager_certificate_ready_status{condition="False",name="testcrt",namespace="testns"} 0
-certmanager_certificate_ready_status{condition="True",name="testcrt",namespace="testns"} 1
-certmanager_certificate_ready_status{condition="Unknown",name="testcrt",namespace="testns"} 0
-` + clockMetric + `
-# HELP certmanager_controller_sync_call_count The number of sync() calls made by a controller.
-# TYPE certmanager_controller_sync_call_count counter
-certmanager_controller_sync_call_count{controller="metrics_test"} 2
-`)
+	waitForSync(3)
+	mfs = framework.FetchMetrics(t, metricsEndpoint)
+	framework.AssertGauge(t, mfs, "certmanager_certificate_expiry_bucket", map[string]string{"name": crtName, "namespace": namespace, "bucket": "expired"}, 1)
+	framework.AssertCounter(t, mfs, "certmanager_certificate_expiry_bucket_total", map[string]string{"issuer_name": "test-issuer", "issuer_kind": "Issuer", "issuer_group": "", "bucket": "expired"}, 1)
 
 	err = cmClient.CertmanagerV1().Certificates(namespace).Delete(ctx, crt.Name, metav1.DeleteOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	// Should expose no Certificates and only metrics sync count increase
-	waitForMetrics(clockMetric + `
-# HELP certmanager_controller_sync_call_count The number of sync() calls made by a controller.
-# TYPE certmanager_controller_sync_call_count counter
-certmanager_controller_sync_call_count{controller="metrics_test"} 3
-`)
+	// Should expose no Certificate series and only the sync count increasing.
+	waitForSync(4)
+	mfs = framework.FetchMetrics(t, metricsEndpoint)
+	framework.AssertAbsent(t, mfs, "certmanager_certificate_expiration_timestamp_seconds", nameNamespaceLabels(crtName, namespace))
+	framework.AssertAbsent(t, mfs, "certmanager_certificate_ready_status", readyStatusLabels(crtName, namespace, "True", "test-issuer", "Issuer", "Ready"))
+	framework.AssertAbsent(t, mfs, "certmanager_certificate_renewal_timestamp_seconds", nameNamespaceLabels(crtName, namespace))
+	framework.AssertAbsent(t, mfs, "certmanager_certificate_expiry_bucket", map[string]string{"name": crtName, "namespace": namespace, "bucket": "expired"})
 }
@@ -0,0 +1,147 @@
+/*
+Copyright 2020 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificates
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+
+	apiutil "github.com/jetstack/cert-manager/pkg/api/util"
+	cmapi "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/jetstack/cert-manager/pkg/apis/meta/v1"
+	controllerpkg "github.com/jetstack/cert-manager/pkg/controller"
+	"github.com/jetstack/cert-manager/pkg/controller/certificates/refresh"
+	logf "github.com/jetstack/cert-manager/pkg/logs"
+	"github.com/jetstack/cert-manager/pkg/metrics"
+	"github.com/jetstack/cert-manager/test/integration/framework"
+	"github.com/jetstack/cert-manager/test/unit/gen"
+)
+
+// TestRefreshController exercises a full new-private-key refresh cycle: the
+// annotation triggers a forced reissuance and a Secret delete, and once the
+// Certificate comes back Ready the controller reports completion and clears
+// the trigger annotation.
+func TestRefreshController(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*40)
+	defer cancel()
+
+	config, stopFn := framework.RunControlPlane(t, ctx)
+	defer stopFn()
+
+	kubeClient, factory, cmClient, cmFactory := framework.NewClients(t, config)
+
+	metricsHandler := metrics.New(logf.Log, fixedClock)
+	recorder := record.NewFakeRecorder(10)
+
+	ctrl, queue, mustSync := refresh.NewController(cmFactory, cmClient, kubeClient, recorder)
+	c := controllerpkg.NewController(
+		ctx,
+		"refresh_test",
+		metricsHandler,
+		ctrl.ProcessItem,
+		mustSync,
+		nil,
+		queue,
+	)
+	stopController := framework.StartInformersAndController(t, factory, cmFactory, c)
+	defer stopController()
+
+	const (
+		crtName   = "testcrt"
+		namespace = "testns"
+	)
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+	if _, err := kubeClient.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: crtName, Namespace: namespace},
+		Data:       map[string][]byte{"tls.crt": []byte("old-cert"), "tls.key": []byte("old-key")},
+	}
+	if _, err := kubeClient.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	crt := gen.Certificate(crtName,
+		gen.SetCertificateNamespace(namespace),
+		gen.SetCertificateSecretName(crtName),
+		gen.SetCertificateCommonName(crtName),
+		gen.SetCertificateIssuer(cmmeta.ObjectReference{Kind: "Issuer", Name: "test-issuer"}),
+		gen.SetCertificateAnnotations(map[string]string{
+			cmapi.RefreshCertificateAnnotationKey: cmapi.RefreshCertificateNewPrivateKeyAnnotationValue,
+		}),
+	)
+	crt, err := cmClient.CertmanagerV1().Certificates(namespace).Create(ctx, crt, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The controller should delete the old Secret and flip Issuing=True,
+	// marking the refresh in-progress.
+	err = wait.PollImmediateUntil(time.Millisecond*100, func() (bool, error) {
+		got, err := cmClient.CertmanagerV1().Certificates(namespace).Get(ctx, crtName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return got.Annotations[cmapi.RefreshCertificateStatusAnnotationKey] == string(cmapi.RefreshCertificateStatusInProgress), nil
+	}, ctx.Done())
+	if err != nil {
+		t.Fatalf("timed out waiting for refresh to start: %v", err)
+	}
+
+	if _, err := kubeClient.CoreV1().Secrets(namespace).Get(ctx, crtName, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected old Secret to be deleted, got err: %v", err)
+	}
+
+	crt, err = cmClient.CertmanagerV1().Certificates(namespace).Get(ctx, crtName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuing := apiutil.GetCertificateCondition(crt, cmapi.CertificateConditionIssuing)
+	if issuing == nil || issuing.Status != cmmeta.ConditionTrue {
+		t.Fatalf("expected Issuing=True, got %+v", issuing)
+	}
+
+	// Simulate the issuing controller completing reissuance.
+	crt.Status.Conditions = []cmapi.CertificateCondition{
+		{Type: cmapi.CertificateConditionReady, Status: cmmeta.ConditionTrue, Reason: "Ready"},
+	}
+	if _, err := cmClient.CertmanagerV1().Certificates(namespace).UpdateStatus(ctx, crt, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	err = wait.PollImmediateUntil(time.Millisecond*100, func() (bool, error) {
+		got, err := cmClient.CertmanagerV1().Certificates(namespace).Get(ctx, crtName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		_, stillRequested := got.Annotations[cmapi.RefreshCertificateAnnotationKey]
+		return !stillRequested && got.Annotations[cmapi.RefreshCertificateStatusAnnotationKey] == string(cmapi.RefreshCertificateStatusDone), nil
+	}, ctx.Done())
+	if err != nil {
+		t.Fatalf("timed out waiting for refresh to complete: %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2020 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package framework provides shared helpers for spinning up a control
+// plane and cert-manager clients/informers for use in integration tests.
+package framework
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	cmclient "github.com/jetstack/cert-manager/pkg/client/clientset/versioned"
+	cminformers "github.com/jetstack/cert-manager/pkg/client/informers/externalversions"
+	controllerpkg "github.com/jetstack/cert-manager/pkg/controller"
+)
+
+// RunControlPlane starts a local etcd/kube-apiserver pair for the duration
+// of the test and returns a *rest.Config for talking to it, along with a
+// function to tear it down.
+func RunControlPlane(t *testing.T, ctx context.Context) (*rest.Config, func()) {
+	t.Helper()
+
+	env := &envtest.Environment{}
+	cfg, err := env.Start()
+	if err != nil {
+		t.Fatalf("failed to start control plane: %v", err)
+	}
+
+	return cfg, func() {
+		if err := env.Stop(); err != nil {
+			t.Logf("failed to stop control plane: %v", err)
+		}
+	}
+}
+
+// NewClients builds a Kubernetes clientset, a cert-manager clientset, and
+// the informer factories for each.
+func NewClients(t *testing.T, cfg *rest.Config) (kubernetes.Interface, informers.SharedInformerFactory, cmclient.Interface, cminformers.SharedInformerFactory) {
+	t.Helper()
+
+	kubeCl, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		t.Fatalf("failed to build kubernetes client: %v", err)
+	}
+
+	cmCl, err := cmclient.NewForConfig(cfg)
+	if err != nil {
+		t.Fatalf("failed to build cert-manager client: %v", err)
+	}
+
+	factory := informers.NewSharedInformerFactory(kubeCl, time.Second*30)
+	cmFactory := cminformers.NewSharedInformerFactory(cmCl, time.Second*30)
+
+	return kubeCl, factory, cmCl, cmFactory
+}
+
+// StartInformersAndController starts the given informer factories and
+// controller, waiting for caches to sync before returning. The returned
+// function stops the controller.
+func StartInformersAndController(t *testing.T, factory informers.SharedInformerFactory, cmFactory cminformers.SharedInformerFactory, c *controllerpkg.Controller) func() {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	factory.Start(ctx.Done())
+	cmFactory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+	cmFactory.WaitForCacheSync(ctx.Done())
+
+	go func() {
+		if err := c.Run(ctx, 1); err != nil {
+			t.Logf("controller exited with error: %v", err)
+		}
+	}()
+
+	return cancel
+}
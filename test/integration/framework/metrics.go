@@ -0,0 +1,148 @@
+/*
+Copyright 2020 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"net/http"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// FetchMetrics scrapes endpoint and parses the response body into a map
+// of metric name to MetricFamily, so that tests can assert on individual
+// series instead of comparing the whole payload as one string.
+func FetchMetrics(t *testing.T, endpoint string) map[string]*dto.MetricFamily {
+	t.Helper()
+
+	resp, err := http.DefaultClient.Get(endpoint)
+	if err != nil {
+		t.Fatalf("failed to scrape %s: %v", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	var parser expfmt.TextParser
+	mfs, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to parse metrics response: %v", err)
+	}
+
+	return mfs
+}
+
+// labelsMatch reports whether metric carries exactly the given label set.
+func labelsMatch(metric *dto.Metric, labels map[string]string) bool {
+	if len(metric.Label) != len(labels) {
+		return false
+	}
+	for _, l := range metric.Label {
+		want, ok := labels[l.GetName()]
+		if !ok || want != l.GetValue() {
+			return false
+		}
+	}
+	return true
+}
+
+// findMetric returns the metric within family mf whose labels exactly
+// match labels, or nil if there is no such series.
+func findMetric(mf *dto.MetricFamily, labels map[string]string) *dto.Metric {
+	for _, metric := range mf.Metric {
+		if labelsMatch(metric, labels) {
+			return metric
+		}
+	}
+	return nil
+}
+
+func metricValue(metric *dto.Metric) float64 {
+	switch {
+	case metric.Gauge != nil:
+		return metric.Gauge.GetValue()
+	case metric.Counter != nil:
+		return metric.Counter.GetValue()
+	default:
+		return 0
+	}
+}
+
+// AssertGauge asserts that mfs contains a gauge series called name with
+// exactly the given labels and value.
+func AssertGauge(t *testing.T, mfs map[string]*dto.MetricFamily, name string, labels map[string]string, value float64) {
+	t.Helper()
+
+	mf, ok := mfs[name]
+	if !ok {
+		t.Errorf("expected gauge %q to be present, but it was absent", name)
+		return
+	}
+
+	metric := findMetric(mf, labels)
+	if metric == nil {
+		t.Errorf("expected gauge %q with labels %v to be present, but no matching series was found", name, labels)
+		return
+	}
+
+	if got := metricValue(metric); got != value {
+		t.Errorf("expected gauge %q with labels %v to equal %v, got %v", name, labels, value, got)
+	}
+}
+
+// AssertCounter asserts that mfs contains a counter series called name
+// with exactly the given labels and value.
+func AssertCounter(t *testing.T, mfs map[string]*dto.MetricFamily, name string, labels map[string]string, value float64) {
+	t.Helper()
+
+	mf, ok := mfs[name]
+	if !ok {
+		t.Errorf("expected counter %q to be present, but it was absent", name)
+		return
+	}
+
+	metric := findMetric(mf, labels)
+	if metric == nil {
+		t.Errorf("expected counter %q with labels %v to be present, but no matching series was found", name, labels)
+		return
+	}
+
+	if got := metricValue(metric); got != value {
+		t.Errorf("expected counter %q with labels %v to equal %v, got %v", name, labels, value, got)
+	}
+}
+
+// AssertAbsent asserts that mfs contains no series called name with the
+// given labels (or, if labels is nil, no series of that name at all).
+func AssertAbsent(t *testing.T, mfs map[string]*dto.MetricFamily, name string, labels map[string]string) {
+	t.Helper()
+
+	mf, ok := mfs[name]
+	if !ok {
+		return
+	}
+
+	if labels == nil {
+		if len(mf.Metric) > 0 {
+			t.Errorf("expected metric %q to be entirely absent, but found %d series", name, len(mf.Metric))
+		}
+		return
+	}
+
+	if metric := findMetric(mf, labels); metric != nil {
+		t.Errorf("expected metric %q with labels %v to be absent, but found value %v", name, labels, metricValue(metric))
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2020 The cert-manager Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gen provides small builder functions for constructing
+// cert-manager API objects in tests.
+package gen
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	cmapi "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/jetstack/cert-manager/pkg/apis/meta/v1"
+)
+
+// CertificateModifier mutates a Certificate being built up by Certificate.
+type CertificateModifier func(*cmapi.Certificate)
+
+// Certificate builds a Certificate with the given name, applying each
+// modifier in order.
+func Certificate(name string, mods ...CertificateModifier) *cmapi.Certificate {
+	crt := &cmapi.Certificate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}
+	for _, mod := range mods {
+		mod(crt)
+	}
+	return crt
+}
+
+// SetCertificateNamespace sets the Certificate's namespace.
+func SetCertificateNamespace(namespace string) CertificateModifier {
+	return func(crt *cmapi.Certificate) { crt.Namespace = namespace }
+}
+
+// SetCertificateUID sets the Certificate's UID.
+func SetCertificateUID(uid string) CertificateModifier {
+	return func(crt *cmapi.Certificate) { crt.UID = types.UID(uid) }
+}
+
+// SetCertificateSecretName sets the Certificate's spec.secretName.
+func SetCertificateSecretName(secretName string) CertificateModifier {
+	return func(crt *cmapi.Certificate) { crt.Spec.SecretName = secretName }
+}
+
+// SetCertificateCommonName sets the Certificate's spec.commonName.
+func SetCertificateCommonName(commonName string) CertificateModifier {
+	return func(crt *cmapi.Certificate) { crt.Spec.CommonName = commonName }
+}
+
+// SetCertificateIssuer sets the Certificate's spec.issuerRef.
+func SetCertificateIssuer(issuerRef cmmeta.ObjectReference) CertificateModifier {
+	return func(crt *cmapi.Certificate) { crt.Spec.IssuerRef = issuerRef }
+}
+
+// SetCertificateAnnotations merges the given annotations into the
+// Certificate's metadata.
+func SetCertificateAnnotations(annotations map[string]string) CertificateModifier {
+	return func(crt *cmapi.Certificate) {
+		if crt.Annotations == nil {
+			crt.Annotations = map[string]string{}
+		}
+		for k, v := range annotations {
+			crt.Annotations[k] = v
+		}
+	}
+}
+
+// SetCertificateNotAfter sets the Certificate's status.notAfter.
+func SetCertificateNotAfter(t metav1.Time) CertificateModifier {
+	return func(crt *cmapi.Certificate) { crt.Status.NotAfter = &t }
+}
+
+// SetCertificateRenewalTime sets the Certificate's status.renewalTime.
+func SetCertificateRenewalTime(t metav1.Time) CertificateModifier {
+	return func(crt *cmapi.Certificate) { crt.Status.RenewalTime = &t }
+}
+
+// SetCertificateStatusCondition appends or replaces a condition of the
+// same type on the Certificate's status.
+func SetCertificateStatusCondition(condition cmapi.CertificateCondition) CertificateModifier {
+	return func(crt *cmapi.Certificate) {
+		for i, c := range crt.Status.Conditions {
+			if c.Type == condition.Type {
+				crt.Status.Conditions[i] = condition
+				return
+			}
+		}
+		crt.Status.Conditions = append(crt.Status.Conditions, condition)
+	}
+}